@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -18,18 +19,20 @@ type SmartContract struct {
 
 // Reservation represents a room reservation
 type Reservation struct {
-	ID          string    `json:"id"`
-	RoomNumber  string    `json:"roomNumber"`
-	Block       string    `json:"block"`
-	Floor       string    `json:"floor"`
-	UserID      string    `json:"userID"`
-	UserType    string    `json:"userType"` // "student" or "professor"
-	StartTime   time.Time `json:"startTime"`
-	EndTime     time.Time `json:"endTime"`
-	Duration    int       `json:"duration"` // in minutes: 60, 90, or 120
-	Status      string    `json:"status"`   // "active" or "cancelled"
-	CreatedAt   time.Time `json:"createdAt"`
-	CancelledBy string    `json:"cancelledBy,omitempty"`
+	ID             string    `json:"id"`
+	RoomNumber     string    `json:"roomNumber"`
+	Block          string    `json:"block"`
+	Floor          string    `json:"floor"`
+	UserID         string    `json:"userID"`
+	UserType       string    `json:"userType"` // "student" or "professor"
+	StartTime      time.Time `json:"startTime"`
+	EndTime        time.Time `json:"endTime"`
+	Duration       int       `json:"duration"` // in minutes: 60, 90, or 120
+	Status         string    `json:"status"`   // "active" or "cancelled"
+	CreatedAt      time.Time `json:"createdAt"`
+	CancelledBy    string    `json:"cancelledBy,omitempty"`
+	SeriesID       string    `json:"seriesID,omitempty"`       // set when the reservation is one occurrence of a recurring series
+	OverrideReason string    `json:"overrideReason,omitempty"` // set when a professor bypassed ReservationPolicy limits
 }
 
 // Room represents a study room
@@ -40,15 +43,131 @@ type Room struct {
 	Status string `json:"status"` // "available" or "occupied"
 }
 
+// TimeSlot represents a contiguous free window, e.g. a gap between reservations
+type TimeSlot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// ReservationSuggestion pairs a room with a candidate start time for a requested duration
+type ReservationSuggestion struct {
+	RoomNumber string    `json:"roomNumber"`
+	Block      string    `json:"block"`
+	Start      time.Time `json:"start"`
+}
+
 // User represents a system user
 type User struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	UserType string `json:"userType"` // "student" or "professor"
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	UserType     string `json:"userType"` // "student" or "professor"
+	Block        string `json:"block"`
+	EnrollmentID string `json:"enrollmentID"` // the ClientIdentity.GetID() value this user authenticates as
+}
+
+// ReservationCreatedEvent is the payload emitted as a "ReservationCreated" chaincode event
+// whenever a single reservation is made.
+type ReservationCreatedEvent struct {
+	ReservationID string    `json:"reservationID"`
+	RoomNumber    string    `json:"roomNumber"`
+	Block         string    `json:"block"`
+	UserID        string    `json:"userID"`
+	StartTime     time.Time `json:"startTime"`
+	EndTime       time.Time `json:"endTime"`
+}
+
+// ReservationCancelledEvent is the payload emitted as a "ReservationCancelled" chaincode event
+// whenever CancelReservation cancels a single reservation (standalone or one occurrence of a
+// series). Cancelling a whole series with CancelReservationSeries emits SeriesCancelled instead.
+type ReservationCancelledEvent struct {
+	ReservationID string `json:"reservationID"`
+	CancelledBy   string `json:"cancelledBy"`
+}
+
+// UserRegisteredEvent is the payload emitted as a "UserRegistered" chaincode event whenever a
+// new user is registered.
+type UserRegisteredEvent struct {
+	UserID   string `json:"userID"`
+	UserType string `json:"userType"`
 	Block    string `json:"block"`
 }
 
+// SeriesCreatedEvent is the payload emitted as a "SeriesCreated" chaincode event whenever a
+// recurring reservation series is booked.
+type SeriesCreatedEvent struct {
+	SeriesID       string   `json:"seriesID"`
+	RoomNumber     string   `json:"roomNumber"`
+	Block          string   `json:"block"`
+	UserID         string   `json:"userID"`
+	ReservationIDs []string `json:"reservationIDs"`
+}
+
+// SeriesCancelledEvent is the payload emitted as a "SeriesCancelled" chaincode event whenever a
+// recurring reservation series is cancelled. A transaction may only ever set one chaincode event,
+// so the cancelled occurrences are aggregated into a single event rather than emitted one per
+// reservation (see MakeRecurringReservation's equivalent aggregation into SeriesCreatedEvent).
+type SeriesCancelledEvent struct {
+	SeriesID       string   `json:"seriesID"`
+	ReservationIDs []string `json:"reservationIDs"`
+	CancelledBy    string   `json:"cancelledBy"`
+}
+
+// ReservationHistoryEntry is one entry in the audit trail returned by GetReservationHistory.
+type ReservationHistoryEntry struct {
+	TxID        string       `json:"txID"`
+	Timestamp   time.Time    `json:"timestamp"`
+	IsDelete    bool         `json:"isDelete"`
+	Reservation *Reservation `json:"reservation,omitempty"`
+}
+
+// emitEvent marshals payload and sets it as a chaincode event under the given name
+func emitEvent(ctx contractapi.TransactionContextInterface, name string, payload interface{}) error {
+	eventJSON, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().SetEvent(name, eventJSON)
+}
+
+// requireRole returns an error unless the calling client identity carries a "role" attribute
+// equal to requiredRole, e.g. requireRole(ctx, "admin") for admin-only operations.
+func requireRole(ctx contractapi.TransactionContextInterface, requiredRole string) error {
+	role, ok, err := ctx.GetClientIdentity().GetAttributeValue("role")
+	if err != nil {
+		return fmt.Errorf("failed to read client identity role attribute: %v", err)
+	}
+	if !ok || role != requiredRole {
+		return fmt.Errorf("only callers with role=%s may perform this operation", requiredRole)
+	}
+
+	return nil
+}
+
+// ReservationPolicy configures the quota, lead-time, and cancellation rules MakeReservation and
+// CancelReservation enforce. It is stored as a single asset under the POLICY key.
+type ReservationPolicy struct {
+	MaxActivePerStudent      int  `json:"maxActivePerStudent"`
+	MaxActivePerProfessor    int  `json:"maxActivePerProfessor"`
+	MaxHoursPerWeekPerUser   int  `json:"maxHoursPerWeekPerUser"`
+	MinLeadTimeMinutes       int  `json:"minLeadTimeMinutes"`
+	MaxAdvanceDays           int  `json:"maxAdvanceDays"`
+	CancellationGraceMinutes int  `json:"cancellationGraceMinutes"`
+	AllowProfessorOverride   bool `json:"allowProfessorOverride"`
+}
+
+// defaultReservationPolicy applies until an administrator calls SetPolicy
+var defaultReservationPolicy = ReservationPolicy{
+	MaxActivePerStudent:      3,
+	MaxActivePerProfessor:    10,
+	MaxHoursPerWeekPerUser:   10,
+	MinLeadTimeMinutes:       30,
+	MaxAdvanceDays:           30,
+	CancellationGraceMinutes: 60,
+	AllowProfessorOverride:   true,
+}
+
 // InitLedger adds initial data to the ledger
 func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
 	// Initialize rooms for blocks 33, 34, and 35
@@ -79,11 +198,86 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 		}
 	}
 
+	// Migration: backfill the room/user composite-key indexes for any reservations that were
+	// written before this indexing scheme existed.
+	resultsIterator, err := ctx.GetStub().GetStateByRange("RESERVATION_", "RESERVATION_~")
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+
+		var reservation Reservation
+		if err := json.Unmarshal(queryResponse.Value, &reservation); err != nil {
+			return err
+		}
+
+		if err := s.putReservationIndexes(ctx, reservation); err != nil {
+			return fmt.Errorf("failed to backfill indexes for reservation %s: %v", reservation.ID, err)
+		}
+	}
+
 	return nil
 }
 
-// RegisterUser registers a new user in the system
-func (s *SmartContract) RegisterUser(ctx contractapi.TransactionContextInterface, userID, name, email, userType, block string) error {
+// SetPolicy replaces the reservation policy. Restricted to callers whose client identity carries
+// a role=admin attribute.
+func (s *SmartContract) SetPolicy(ctx contractapi.TransactionContextInterface, maxActivePerStudent, maxActivePerProfessor, maxHoursPerWeekPerUser, minLeadTimeMinutes, maxAdvanceDays, cancellationGraceMinutes int, allowProfessorOverride bool) error {
+	if err := requireRole(ctx, "admin"); err != nil {
+		return err
+	}
+
+	policy := ReservationPolicy{
+		MaxActivePerStudent:      maxActivePerStudent,
+		MaxActivePerProfessor:    maxActivePerProfessor,
+		MaxHoursPerWeekPerUser:   maxHoursPerWeekPerUser,
+		MinLeadTimeMinutes:       minLeadTimeMinutes,
+		MaxAdvanceDays:           maxAdvanceDays,
+		CancellationGraceMinutes: cancellationGraceMinutes,
+		AllowProfessorOverride:   allowProfessorOverride,
+	}
+
+	policyJSON, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState("POLICY", policyJSON)
+}
+
+// GetPolicy returns the current reservation policy, or defaultReservationPolicy if none has
+// been set yet.
+func (s *SmartContract) GetPolicy(ctx contractapi.TransactionContextInterface) (*ReservationPolicy, error) {
+	policyBytes, err := ctx.GetStub().GetState("POLICY")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %v", err)
+	}
+	if policyBytes == nil {
+		policy := defaultReservationPolicy
+		return &policy, nil
+	}
+
+	var policy ReservationPolicy
+	if err := json.Unmarshal(policyBytes, &policy); err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// RegisterUser registers a new user in the system and links them to the client identity they
+// will authenticate as (enrollmentID, typically obtained when the registrar enrolls them with
+// the CA). Restricted to callers whose client identity carries a role=registrar attribute.
+func (s *SmartContract) RegisterUser(ctx contractapi.TransactionContextInterface, userID, name, email, userType, block, enrollmentID string) error {
+	if err := requireRole(ctx, "registrar"); err != nil {
+		return err
+	}
+
 	// Validate user type
 	if userType != "student" && userType != "professor" {
 		return fmt.Errorf("invalid user type: %s. Must be 'student' or 'professor'", userType)
@@ -95,11 +289,12 @@ func (s *SmartContract) RegisterUser(ctx contractapi.TransactionContextInterface
 	}
 
 	user := User{
-		ID:       userID,
-		Name:     name,
-		Email:    email,
-		UserType: userType,
-		Block:    block,
+		ID:           userID,
+		Name:         name,
+		Email:        email,
+		UserType:     userType,
+		Block:        block,
+		EnrollmentID: enrollmentID,
 	}
 
 	userJSON, err := json.Marshal(user)
@@ -107,11 +302,67 @@ func (s *SmartContract) RegisterUser(ctx contractapi.TransactionContextInterface
 		return err
 	}
 
-	return ctx.GetStub().PutState("USER_"+userID, userJSON)
+	if err := ctx.GetStub().PutState("USER_"+userID, userJSON); err != nil {
+		return err
+	}
+
+	enrollmentIndexKey, err := ctx.GetStub().CreateCompositeKey("idx~enrollment", []string{enrollmentID, userID})
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(enrollmentIndexKey, []byte{0x00}); err != nil {
+		return fmt.Errorf("failed to put enrollment index to world state: %v", err)
+	}
+
+	return emitEvent(ctx, "UserRegistered", UserRegisteredEvent{UserID: userID, UserType: userType, Block: block})
+}
+
+// resolveActingUser determines which registered User is acting in the current transaction.
+// Ordinarily this is whichever User is linked (via the idx~enrollment index) to the calling
+// client identity. If adminOverrideUserID is supplied, the caller must carry a role=admin
+// client identity attribute, and that user record is used instead — e.g. for support staff
+// acting on a student's behalf.
+func (s *SmartContract) resolveActingUser(ctx contractapi.TransactionContextInterface, adminOverrideUserID string) (*User, error) {
+	if adminOverrideUserID != "" {
+		if err := requireRole(ctx, "admin"); err != nil {
+			return nil, err
+		}
+		return s.GetUser(ctx, adminOverrideUserID)
+	}
+
+	enrollmentID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client identity: %v", err)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("idx~enrollment", []string{enrollmentID})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	if !resultsIterator.HasNext() {
+		return nil, fmt.Errorf("no registered user is linked to the calling identity")
+	}
+
+	queryResponse, err := resultsIterator.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetUser(ctx, attributes[len(attributes)-1])
 }
 
-// MakeReservation creates a new room reservation
-func (s *SmartContract) MakeReservation(ctx contractapi.TransactionContextInterface, reservationID, roomNumber, block, userID, startTimeStr string, duration int) error {
+// MakeReservation creates a new room reservation for the calling identity's linked user (see
+// resolveActingUser). It enforces the current ReservationPolicy (quota, lead-time, and
+// advance-booking limits) unless overrideReason is non-empty, in which case the acting user
+// must be a professor and AllowProfessorOverride must be enabled.
+func (s *SmartContract) MakeReservation(ctx contractapi.TransactionContextInterface, reservationID, roomNumber, block, startTimeStr string, duration int, overrideReason, adminOverrideUserID string) error {
 	// Validate duration (60, 90, or 120 minutes)
 	if duration != 60 && duration != 90 && duration != 120 {
 		return fmt.Errorf("invalid duration: %d. Must be 60, 90, or 120 minutes", duration)
@@ -131,21 +382,56 @@ func (s *SmartContract) MakeReservation(ctx contractapi.TransactionContextInterf
 		return fmt.Errorf("reservations are only allowed between 06:00 and 23:00")
 	}
 
-	// Get user information
-	userBytes, err := ctx.GetStub().GetState("USER_" + userID)
+	// Resolve the acting user from the caller's client identity (or an admin override)
+	actingUser, err := s.resolveActingUser(ctx, adminOverrideUserID)
 	if err != nil {
-		return fmt.Errorf("failed to read user: %v", err)
-	}
-	if userBytes == nil {
-		return fmt.Errorf("user %s does not exist", userID)
+		return err
 	}
+	user := *actingUser
+	userID := user.ID
 
-	var user User
-	err = json.Unmarshal(userBytes, &user)
+	policy, err := s.GetPolicy(ctx)
 	if err != nil {
 		return err
 	}
 
+	if overrideReason != "" {
+		if !policy.AllowProfessorOverride {
+			return fmt.Errorf("policy overrides are disabled")
+		}
+		if user.UserType != "professor" {
+			return fmt.Errorf("only professors may override reservation policy limits")
+		}
+	} else {
+		now, err := txTime(ctx)
+		if err != nil {
+			return err
+		}
+		leadTime := startTime.Sub(now)
+		if leadTime < time.Duration(policy.MinLeadTimeMinutes)*time.Minute {
+			return fmt.Errorf("reservations must be made at least %d minutes in advance", policy.MinLeadTimeMinutes)
+		}
+		if leadTime > time.Duration(policy.MaxAdvanceDays)*24*time.Hour {
+			return fmt.Errorf("reservations cannot be made more than %d days in advance", policy.MaxAdvanceDays)
+		}
+
+		activeCount, activeMinutes, err := s.activeReservationLoadForWeek(ctx, userID, startTime)
+		if err != nil {
+			return err
+		}
+
+		maxActive := policy.MaxActivePerStudent
+		if user.UserType == "professor" {
+			maxActive = policy.MaxActivePerProfessor
+		}
+		if activeCount >= maxActive {
+			return fmt.Errorf("user %s has reached the maximum of %d active reservations", userID, maxActive)
+		}
+		if activeMinutes+duration > policy.MaxHoursPerWeekPerUser*60 {
+			return fmt.Errorf("user %s would exceed the %d hour weekly reservation limit", userID, policy.MaxHoursPerWeekPerUser)
+		}
+	}
+
 	// Extract floor from room number
 	floor := string(roomNumber[0])
 
@@ -170,29 +456,37 @@ func (s *SmartContract) MakeReservation(ctx contractapi.TransactionContextInterf
 
 	// Create reservation
 	reservation := Reservation{
-		ID:         reservationID,
-		RoomNumber: roomNumber,
-		Block:      block,
-		Floor:      floor,
-		UserID:     userID,
-		UserType:   user.UserType,
-		StartTime:  startTime,
-		EndTime:    endTime,
-		Duration:   duration,
-		Status:     "active",
-		CreatedAt:  time.Now(),
+		ID:             reservationID,
+		RoomNumber:     roomNumber,
+		Block:          block,
+		Floor:          floor,
+		UserID:         userID,
+		UserType:       user.UserType,
+		StartTime:      startTime,
+		EndTime:        endTime,
+		Duration:       duration,
+		Status:         "active",
+		CreatedAt:      time.Now(),
+		OverrideReason: overrideReason,
 	}
 
-	reservationJSON, err := json.Marshal(reservation)
-	if err != nil {
+	if err := s.putReservationWithIndexes(ctx, reservation); err != nil {
 		return err
 	}
 
-	return ctx.GetStub().PutState("RESERVATION_"+reservationID, reservationJSON)
+	return emitEvent(ctx, "ReservationCreated", ReservationCreatedEvent{
+		ReservationID: reservation.ID,
+		RoomNumber:    reservation.RoomNumber,
+		Block:         reservation.Block,
+		UserID:        reservation.UserID,
+		StartTime:     reservation.StartTime,
+		EndTime:       reservation.EndTime,
+	})
 }
 
-// CancelReservation cancels an existing reservation
-func (s *SmartContract) CancelReservation(ctx contractapi.TransactionContextInterface, reservationID, cancelledByUserID string) error {
+// CancelReservation cancels an existing reservation on behalf of the calling identity's linked
+// user (see resolveActingUser).
+func (s *SmartContract) CancelReservation(ctx contractapi.TransactionContextInterface, reservationID, adminOverrideUserID string) error {
 	// Get the reservation
 	reservationBytes, err := ctx.GetStub().GetState("RESERVATION_" + reservationID)
 	if err != nil {
@@ -208,20 +502,13 @@ func (s *SmartContract) CancelReservation(ctx contractapi.TransactionContextInte
 		return err
 	}
 
-	// Get the user who is cancelling
-	userBytes, err := ctx.GetStub().GetState("USER_" + cancelledByUserID)
-	if err != nil {
-		return fmt.Errorf("failed to read user: %v", err)
-	}
-	if userBytes == nil {
-		return fmt.Errorf("user %s does not exist", cancelledByUserID)
-	}
-
-	var cancellingUser User
-	err = json.Unmarshal(userBytes, &cancellingUser)
+	// Resolve the user who is cancelling from the caller's client identity (or an admin override)
+	actingUser, err := s.resolveActingUser(ctx, adminOverrideUserID)
 	if err != nil {
 		return err
 	}
+	cancellingUser := *actingUser
+	cancelledByUserID := cancellingUser.ID
 
 	// Check permissions: professors can cancel any reservation, students can only cancel their own
 	if cancellingUser.UserType == "student" && reservation.UserID != cancelledByUserID {
@@ -233,6 +520,24 @@ func (s *SmartContract) CancelReservation(ctx contractapi.TransactionContextInte
 		return fmt.Errorf("reservation %s is already cancelled", reservationID)
 	}
 
+	// Students must cancel before the policy's grace period closes; professors are exempt
+	if cancellingUser.UserType == "student" {
+		policy, err := s.GetPolicy(ctx)
+		if err != nil {
+			return err
+		}
+
+		now, err := txTime(ctx)
+		if err != nil {
+			return err
+		}
+
+		graceDeadline := reservation.StartTime.Add(-time.Duration(policy.CancellationGraceMinutes) * time.Minute)
+		if now.After(graceDeadline) {
+			return fmt.Errorf("reservations must be cancelled at least %d minutes before the start time", policy.CancellationGraceMinutes)
+		}
+	}
+
 	// Update reservation status
 	reservation.Status = "cancelled"
 	reservation.CancelledBy = cancelledByUserID
@@ -242,7 +547,401 @@ func (s *SmartContract) CancelReservation(ctx contractapi.TransactionContextInte
 		return err
 	}
 
-	return ctx.GetStub().PutState("RESERVATION_"+reservationID, reservationJSON)
+	if err := ctx.GetStub().PutState("RESERVATION_"+reservationID, reservationJSON); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, "ReservationCancelled", ReservationCancelledEvent{ReservationID: reservationID, CancelledBy: cancelledByUserID})
+}
+
+// MakeRecurringReservation creates a series of reservations expanded from an iCalendar-style
+// recurrence rule (e.g. "FREQ=WEEKLY;BYDAY=MO,WE;INTERVAL=1;COUNT=8") for the calling identity's
+// linked user (see resolveActingUser). Every occurrence is validated, checked against the current
+// ReservationPolicy (quota, lead-time, and advance-booking limits, unless overriding as a
+// professor), and conflict-checked before any reservation is written, so the whole series is
+// rejected together if a single occurrence cannot be booked.
+func (s *SmartContract) MakeRecurringReservation(ctx contractapi.TransactionContextInterface, seriesID, roomNumber, block, startTimeStr string, duration int, rrule, overrideReason, adminOverrideUserID string) error {
+	// Validate duration (60, 90, or 120 minutes)
+	if duration != 60 && duration != 90 && duration != 120 {
+		return fmt.Errorf("invalid duration: %d. Must be 60, 90, or 120 minutes", duration)
+	}
+
+	firstStart, err := time.Parse("2006-01-02 15:04", startTimeStr)
+	if err != nil {
+		return fmt.Errorf("invalid start time format: %s. Use YYYY-MM-DD HH:MM", startTimeStr)
+	}
+
+	occurrences, err := expandRecurrenceRule(firstStart, rrule)
+	if err != nil {
+		return fmt.Errorf("invalid recurrence rule: %v", err)
+	}
+
+	// Resolve the acting user from the caller's client identity (or an admin override)
+	actingUser, err := s.resolveActingUser(ctx, adminOverrideUserID)
+	if err != nil {
+		return err
+	}
+	user := *actingUser
+	userID := user.ID
+
+	policy, err := s.GetPolicy(ctx)
+	if err != nil {
+		return err
+	}
+
+	overriding := overrideReason != ""
+	if overriding {
+		if !policy.AllowProfessorOverride {
+			return fmt.Errorf("policy overrides are disabled")
+		}
+		if user.UserType != "professor" {
+			return fmt.Errorf("only professors may override reservation policy limits")
+		}
+	}
+
+	maxActive := policy.MaxActivePerStudent
+	if user.UserType == "professor" {
+		maxActive = policy.MaxActivePerProfessor
+	}
+
+	var now time.Time
+	if !overriding {
+		now, err = txTime(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Extract floor from room number
+	floor := string(roomNumber[0])
+
+	// Check if room exists
+	roomKey := "ROOM_" + block + "_" + roomNumber
+	roomBytes, err := ctx.GetStub().GetState(roomKey)
+	if err != nil {
+		return fmt.Errorf("failed to read room: %v", err)
+	}
+	if roomBytes == nil {
+		return fmt.Errorf("room %s in block %s does not exist", roomNumber, block)
+	}
+
+	// Tracks how much of each week's quota this not-yet-committed series has already claimed, so
+	// occurrences within the same series are checked against each other as well as existing bookings
+	stagedCountByWeek := make(map[string]int)
+	stagedMinutesByWeek := make(map[string]int)
+
+	reservations := make([]Reservation, 0, len(occurrences))
+	for i, occStart := range occurrences {
+		occEnd := occStart.Add(time.Duration(duration) * time.Minute)
+
+		// Validate time range (06:00 to 23:00) for every occurrence
+		windowEnd := time.Date(occStart.Year(), occStart.Month(), occStart.Day(), 23, 0, 0, 0, occStart.Location())
+		if occStart.Hour() < 6 || occStart.Hour() >= 23 || occEnd.After(windowEnd) {
+			return fmt.Errorf("occurrence %s is outside the 06:00-23:00 reservation window", occStart.Format("2006-01-02 15:04"))
+		}
+
+		if !overriding {
+			leadTime := occStart.Sub(now)
+			if leadTime < time.Duration(policy.MinLeadTimeMinutes)*time.Minute {
+				return fmt.Errorf("occurrence %s is less than the required %d minutes lead time", occStart.Format("2006-01-02 15:04"), policy.MinLeadTimeMinutes)
+			}
+			if leadTime > time.Duration(policy.MaxAdvanceDays)*24*time.Hour {
+				return fmt.Errorf("occurrence %s is more than the allowed %d days in advance", occStart.Format("2006-01-02 15:04"), policy.MaxAdvanceDays)
+			}
+
+			weekKey := startOfWeek(occStart).Format("2006-01-02")
+			baseCount, baseMinutes, err := s.activeReservationLoadForWeek(ctx, userID, occStart)
+			if err != nil {
+				return err
+			}
+
+			totalCount := baseCount + stagedCountByWeek[weekKey]
+			totalMinutes := baseMinutes + stagedMinutesByWeek[weekKey] + duration
+			if totalCount >= maxActive {
+				return fmt.Errorf("user %s would exceed the maximum of %d active reservations in the week of %s", userID, maxActive, weekKey)
+			}
+			if totalMinutes > policy.MaxHoursPerWeekPerUser*60 {
+				return fmt.Errorf("user %s would exceed the %d hour weekly reservation limit in the week of %s", userID, policy.MaxHoursPerWeekPerUser, weekKey)
+			}
+
+			stagedCountByWeek[weekKey]++
+			stagedMinutesByWeek[weekKey] += duration
+		}
+
+		// Check for conflicts against already-committed reservations
+		conflict, err := s.hasConflictingReservation(ctx, roomNumber, block, occStart, occEnd, "")
+		if err != nil {
+			return fmt.Errorf("error checking for conflicts: %v", err)
+		}
+		if conflict {
+			return fmt.Errorf("room %s in block %s is already reserved for occurrence %s", roomNumber, block, occStart.Format("2006-01-02 15:04"))
+		}
+
+		// Check for conflicts among the occurrences of this same series
+		for _, other := range reservations {
+			if occStart.Before(other.EndTime) && occEnd.After(other.StartTime) {
+				return fmt.Errorf("occurrence %s overlaps with another occurrence in the same series", occStart.Format("2006-01-02 15:04"))
+			}
+		}
+
+		reservations = append(reservations, Reservation{
+			ID:             fmt.Sprintf("%s-%03d", seriesID, i+1),
+			RoomNumber:     roomNumber,
+			Block:          block,
+			Floor:          floor,
+			UserID:         userID,
+			UserType:       user.UserType,
+			StartTime:      occStart,
+			EndTime:        occEnd,
+			Duration:       duration,
+			Status:         "active",
+			CreatedAt:      time.Now(),
+			SeriesID:       seriesID,
+			OverrideReason: overrideReason,
+		})
+	}
+
+	if len(reservations) == 0 {
+		return fmt.Errorf("recurrence rule %s produced no occurrences", rrule)
+	}
+
+	reservationIDs := make([]string, 0, len(reservations))
+	for _, reservation := range reservations {
+		if err := s.putReservationWithIndexes(ctx, reservation); err != nil {
+			return err
+		}
+		reservationIDs = append(reservationIDs, reservation.ID)
+	}
+
+	return emitEvent(ctx, "SeriesCreated", SeriesCreatedEvent{
+		SeriesID:       seriesID,
+		RoomNumber:     roomNumber,
+		Block:          block,
+		UserID:         userID,
+		ReservationIDs: reservationIDs,
+	})
+}
+
+// CancelReservationSeries cancels every active reservation belonging to a recurring series,
+// applying the same authorization rules and cancellation grace period as CancelReservation.
+func (s *SmartContract) CancelReservationSeries(ctx contractapi.TransactionContextInterface, seriesID, adminOverrideUserID string) error {
+	seriesReservations, err := s.GetReservationsBySeries(ctx, seriesID)
+	if err != nil {
+		return err
+	}
+	if len(seriesReservations) == 0 {
+		return fmt.Errorf("series %s does not exist", seriesID)
+	}
+
+	actingUser, err := s.resolveActingUser(ctx, adminOverrideUserID)
+	if err != nil {
+		return err
+	}
+	cancellingUser := *actingUser
+	cancelledByUserID := cancellingUser.ID
+
+	var now time.Time
+	var policy *ReservationPolicy
+	if cancellingUser.UserType == "student" {
+		policy, err = s.GetPolicy(ctx)
+		if err != nil {
+			return err
+		}
+		now, err = txTime(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, reservation := range seriesReservations {
+		if cancellingUser.UserType == "student" && reservation.UserID != cancelledByUserID {
+			return fmt.Errorf("students can only cancel their own reservations")
+		}
+
+		if cancellingUser.UserType == "student" && reservation.Status == "active" {
+			graceDeadline := reservation.StartTime.Add(-time.Duration(policy.CancellationGraceMinutes) * time.Minute)
+			if now.After(graceDeadline) {
+				return fmt.Errorf("occurrence %s must be cancelled at least %d minutes before its start time", reservation.ID, policy.CancellationGraceMinutes)
+			}
+		}
+	}
+
+	var cancelledReservationIDs []string
+	for _, reservation := range seriesReservations {
+		if reservation.Status == "cancelled" {
+			continue
+		}
+
+		reservation.Status = "cancelled"
+		reservation.CancelledBy = cancelledByUserID
+
+		reservationJSON, err := json.Marshal(reservation)
+		if err != nil {
+			return err
+		}
+		if err := ctx.GetStub().PutState("RESERVATION_"+reservation.ID, reservationJSON); err != nil {
+			return fmt.Errorf("failed to put reservation to world state: %v", err)
+		}
+
+		cancelledReservationIDs = append(cancelledReservationIDs, reservation.ID)
+	}
+
+	return emitEvent(ctx, "SeriesCancelled", SeriesCancelledEvent{
+		SeriesID:       seriesID,
+		ReservationIDs: cancelledReservationIDs,
+		CancelledBy:    cancelledByUserID,
+	})
+}
+
+// GetReservationsBySeries returns all reservations belonging to a recurring series
+func (s *SmartContract) GetReservationsBySeries(ctx contractapi.TransactionContextInterface, seriesID string) ([]*Reservation, error) {
+	allReservations, err := s.GetAllReservations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var seriesReservations []*Reservation
+	for _, reservation := range allReservations {
+		if reservation.SeriesID == seriesID {
+			seriesReservations = append(seriesReservations, reservation)
+		}
+	}
+
+	return seriesReservations, nil
+}
+
+// expandRecurrenceRule expands an iCalendar-style RRULE (a subset covering FREQ, INTERVAL,
+// BYDAY, COUNT and UNTIL) into a list of occurrence start times, beginning at firstStart. With
+// BYDAY, firstStart is only an occurrence if its weekday is listed; otherwise the series begins
+// at the first listed weekday after it. FREQ may be "DAILY" or "WEEKLY". Exactly one of COUNT or UNTIL should be
+// supplied to bound the series; if neither is present the series is capped at 366 occurrences.
+func expandRecurrenceRule(firstStart time.Time, rrule string) ([]time.Time, error) {
+	const maxOccurrences = 366
+
+	freq := ""
+	interval := 1
+	count := 0
+	var until time.Time
+	hasUntil := false
+	var byday []time.Weekday
+
+	weekdayByAbbrev := map[string]time.Weekday{
+		"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+		"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+	}
+
+	for _, part := range strings.Split(rrule, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("malformed recurrence rule part: %s", part)
+		}
+		key, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		switch key {
+		case "FREQ":
+			if value != "DAILY" && value != "WEEKLY" {
+				return nil, fmt.Errorf("unsupported FREQ: %s. Must be DAILY or WEEKLY", value)
+			}
+			freq = value
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL: %s", value)
+			}
+			interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid COUNT: %s", value)
+			}
+			count = n
+		case "UNTIL":
+			t, err := time.Parse("20060102T150405Z", value)
+			if err != nil {
+				t, err = time.Parse("2006-01-02", value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid UNTIL: %s", value)
+				}
+			}
+			until = t
+			hasUntil = true
+		case "BYDAY":
+			for _, d := range strings.Split(value, ",") {
+				wd, ok := weekdayByAbbrev[d]
+				if !ok {
+					return nil, fmt.Errorf("invalid BYDAY value: %s", d)
+				}
+				byday = append(byday, wd)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported recurrence rule key: %s", key)
+		}
+	}
+
+	if freq == "" {
+		return nil, fmt.Errorf("recurrence rule must include FREQ")
+	}
+	if count == 0 && !hasUntil {
+		count = maxOccurrences
+	}
+
+	var occurrences []time.Time
+	if freq == "DAILY" || len(byday) == 0 {
+		cursor := firstStart
+		for len(occurrences) < maxOccurrences {
+			if hasUntil && cursor.After(until) {
+				break
+			}
+			occurrences = append(occurrences, cursor)
+			if count > 0 && len(occurrences) >= count {
+				break
+			}
+			if freq == "DAILY" {
+				cursor = cursor.AddDate(0, 0, interval)
+			} else {
+				cursor = cursor.AddDate(0, 0, 7*interval)
+			}
+		}
+		return occurrences, nil
+	}
+
+	// WEEKLY with BYDAY: for each interval week, compute the date of each requested weekday within it
+	weekStart := firstStart
+	for week := 0; len(occurrences) < maxOccurrences; week++ {
+		for _, wd := range byday {
+			offset := (int(wd) - int(weekStart.Weekday()) + 7) % 7
+			candidate := time.Date(weekStart.Year(), weekStart.Month(), weekStart.Day(), firstStart.Hour(), firstStart.Minute(), 0, 0, weekStart.Location()).AddDate(0, 0, offset)
+			if candidate.Before(firstStart) {
+				continue
+			}
+			if hasUntil && candidate.After(until) {
+				continue
+			}
+			occurrences = append(occurrences, candidate)
+		}
+
+		sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].Before(occurrences[j]) })
+
+		if count > 0 && len(occurrences) >= count {
+			occurrences = occurrences[:count]
+			break
+		}
+		if hasUntil && len(occurrences) > 0 && !occurrences[len(occurrences)-1].Before(until) {
+			break
+		}
+
+		weekStart = weekStart.AddDate(0, 0, 7*interval)
+		if hasUntil && weekStart.After(until) {
+			break
+		}
+	}
+
+	return occurrences, nil
 }
 
 // GetReservation returns a specific reservation
@@ -290,16 +989,79 @@ func (s *SmartContract) GetAllReservations(ctx contractapi.TransactionContextInt
 	return reservations, nil
 }
 
-// GetReservationsByRoom returns all reservations for a specific room
+// putReservationWithIndexes writes a reservation to world state along with its room and user
+// composite-key indexes, so room and user lookups don't have to fall back to a full range scan.
+func (s *SmartContract) putReservationWithIndexes(ctx contractapi.TransactionContextInterface, reservation Reservation) error {
+	reservationJSON, err := json.Marshal(reservation)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.GetStub().PutState("RESERVATION_"+reservation.ID, reservationJSON); err != nil {
+		return fmt.Errorf("failed to put reservation to world state: %v", err)
+	}
+
+	return s.putReservationIndexes(ctx, reservation)
+}
+
+// putReservationIndexes writes the idx~room and idx~user composite keys for a reservation,
+// bucketed by the reservation's start date so partial-key queries can scope to a room/user and
+// (optionally) a specific day without a full scan.
+func (s *SmartContract) putReservationIndexes(ctx contractapi.TransactionContextInterface, reservation Reservation) error {
+	startDate := reservation.StartTime.Format("2006-01-02")
+
+	roomIndexKey, err := ctx.GetStub().CreateCompositeKey("idx~room", []string{reservation.Block, reservation.RoomNumber, startDate, reservation.ID})
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(roomIndexKey, []byte{0x00}); err != nil {
+		return fmt.Errorf("failed to put room index to world state: %v", err)
+	}
+
+	userIndexKey, err := ctx.GetStub().CreateCompositeKey("idx~user", []string{reservation.UserID, startDate, reservation.ID})
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(userIndexKey, []byte{0x00}); err != nil {
+		return fmt.Errorf("failed to put user index to world state: %v", err)
+	}
+
+	return nil
+}
+
+// reservationFromIndexKey resolves a composite index key to its underlying reservation, reading
+// the reservation ID back out of the key's trailing attribute.
+func (s *SmartContract) reservationFromIndexKey(ctx contractapi.TransactionContextInterface, compositeKey string) (*Reservation, error) {
+	_, attributes, err := ctx.GetStub().SplitCompositeKey(compositeKey)
+	if err != nil {
+		return nil, err
+	}
+
+	reservationID := attributes[len(attributes)-1]
+	return s.GetReservation(ctx, reservationID)
+}
+
+// GetReservationsByRoom returns all active reservations for a specific room, looked up via the
+// idx~room composite-key index instead of scanning every reservation.
 func (s *SmartContract) GetReservationsByRoom(ctx contractapi.TransactionContextInterface, roomNumber, block string) ([]*Reservation, error) {
-	allReservations, err := s.GetAllReservations(ctx)
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("idx~room", []string{block, roomNumber})
 	if err != nil {
 		return nil, err
 	}
+	defer resultsIterator.Close()
 
 	var roomReservations []*Reservation
-	for _, reservation := range allReservations {
-		if reservation.RoomNumber == roomNumber && reservation.Block == block && reservation.Status == "active" {
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		reservation, err := s.reservationFromIndexKey(ctx, queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		if reservation.Status == "active" {
 			roomReservations = append(roomReservations, reservation)
 		}
 	}
@@ -307,24 +1069,34 @@ func (s *SmartContract) GetReservationsByRoom(ctx contractapi.TransactionContext
 	return roomReservations, nil
 }
 
-// GetReservationsByUser returns all reservations for a specific user
+// GetReservationsByUser returns all reservations for a specific user, looked up via the
+// idx~user composite-key index instead of scanning every reservation.
 func (s *SmartContract) GetReservationsByUser(ctx contractapi.TransactionContextInterface, userID string) ([]*Reservation, error) {
-	allReservations, err := s.GetAllReservations(ctx)
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("idx~user", []string{userID})
 	if err != nil {
 		return nil, err
 	}
+	defer resultsIterator.Close()
 
 	var userReservations []*Reservation
-	for _, reservation := range allReservations {
-		if reservation.UserID == userID {
-			userReservations = append(userReservations, reservation)
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		reservation, err := s.reservationFromIndexKey(ctx, queryResponse.Key)
+		if err != nil {
+			return nil, err
 		}
+		userReservations = append(userReservations, reservation)
 	}
 
 	return userReservations, nil
 }
 
-// GetAvailableRooms returns all available rooms for a specific time period
+// GetAvailableRooms returns all available rooms for a specific time period. Conflict checking
+// is delegated to hasConflictingReservation, which is itself backed by the idx~room index.
 func (s *SmartContract) GetAvailableRooms(ctx contractapi.TransactionContextInterface, startTimeStr, endTimeStr string) ([]*Room, error) {
 	startTime, err := time.Parse("2006-01-02 15:04", startTimeStr)
 	if err != nil {
@@ -423,7 +1195,62 @@ func (s *SmartContract) hasConflictingReservation(ctx contractapi.TransactionCon
 	return false, nil
 }
 
-// GetReservationsByDateRange returns reservations within a date range
+// txTime returns the transaction's agreed timestamp (ctx.GetStub().GetTxTimestamp()) as a
+// time.Time. Pass/fail decisions must branch on this instead of time.Now(), since GetTxTimestamp
+// is the same value across all endorsers while each peer's local wall clock is not; branching on
+// local time near a lead-time/grace-period boundary can make endorsers disagree and fail the
+// endorsement policy.
+func txTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+
+	return time.Unix(ts.Seconds, int64(ts.Nanos)).UTC(), nil
+}
+
+// startOfWeek returns midnight on the Monday of the week containing t
+func startOfWeek(t time.Time) time.Time {
+	weekday := int(t.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	dayStart := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return dayStart.AddDate(0, 0, -(weekday - 1))
+}
+
+// activeReservationLoadForWeek returns the number of active reservations and total reserved
+// minutes a user holds in the week containing reference, for ReservationPolicy enforcement.
+func (s *SmartContract) activeReservationLoadForWeek(ctx contractapi.TransactionContextInterface, userID string, reference time.Time) (int, int, error) {
+	weekStart := startOfWeek(reference)
+	weekEnd := weekStart.AddDate(0, 0, 7)
+
+	userReservations, err := s.GetReservationsByUser(ctx, userID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	count := 0
+	minutes := 0
+	for _, reservation := range userReservations {
+		if reservation.Status != "active" {
+			continue
+		}
+		if reservation.StartTime.Before(weekStart) || !reservation.StartTime.Before(weekEnd) {
+			continue
+		}
+		count++
+		minutes += reservation.Duration
+	}
+
+	return count, minutes, nil
+}
+
+// GetReservationsByDateRange returns active reservations within a date range. It first attempts
+// a CouchDB Mango rich query; if the peer's ledger state database doesn't support rich queries
+// (goleveldb), GetQueryResult itself reports that, and this falls back to filtering a full scan.
+// There is no reliable peer-side signal chaincode can read up front, so probing is the only way
+// to know which path actually works.
 func (s *SmartContract) GetReservationsByDateRange(ctx contractapi.TransactionContextInterface, startDateStr, endDateStr string) ([]*Reservation, error) {
 	startDate, err := time.Parse("2006-01-02", startDateStr)
 	if err != nil {
@@ -438,6 +1265,14 @@ func (s *SmartContract) GetReservationsByDateRange(ctx contractapi.TransactionCo
 	// Add 24 hours to end date to include the entire day
 	endDate = endDate.Add(24 * time.Hour)
 
+	reservations, err := s.getReservationsByDateRangeCouchDB(ctx, startDate, endDate)
+	if err == nil {
+		return reservations, nil
+	}
+	if !isRichQueryUnsupported(err) {
+		return nil, err
+	}
+
 	allReservations, err := s.GetAllReservations(ctx)
 	if err != nil {
 		return nil, err
@@ -453,6 +1288,172 @@ func (s *SmartContract) GetReservationsByDateRange(ctx contractapi.TransactionCo
 	return filteredReservations, nil
 }
 
+// isRichQueryUnsupported reports whether err is the peer's "GetQueryResult not supported for
+// leveldb"-style rejection rather than a real query failure worth surfacing to the caller.
+func isRichQueryUnsupported(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "not supported")
+}
+
+// getReservationsByDateRangeCouchDB runs a Mango selector query over startTime/status, which
+// CouchDB can answer without a full scan.
+func (s *SmartContract) getReservationsByDateRangeCouchDB(ctx contractapi.TransactionContextInterface, startDate, endDate time.Time) ([]*Reservation, error) {
+	queryString := fmt.Sprintf(`{"selector":{"status":"active","startTime":{"$gte":"%s","$lt":"%s"}}}`,
+		startDate.Format(time.RFC3339), endDate.Format(time.RFC3339))
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute rich query: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var reservations []*Reservation
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var reservation Reservation
+		if err := json.Unmarshal(queryResponse.Value, &reservation); err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, &reservation)
+	}
+
+	return reservations, nil
+}
+
+// maxSuggestedReservationTimes bounds how many (room, start) suggestions SuggestReservationTimes
+// returns, so clients get a short, ranked picker list instead of every open slot in the block.
+const maxSuggestedReservationTimes = 10
+
+// GetRoomAvailabilitySlots returns the free time windows for a room on a given date, within the
+// 06:00-23:00 reservation window, filtered to windows at least minDurationMinutes long.
+func (s *SmartContract) GetRoomAvailabilitySlots(ctx contractapi.TransactionContextInterface, block, roomNumber, dateStr string, minDurationMinutes int) ([]*TimeSlot, error) {
+	date, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date format: %s. Use YYYY-MM-DD", dateStr)
+	}
+
+	dayStart := time.Date(date.Year(), date.Month(), date.Day(), 6, 0, 0, 0, date.Location())
+	dayEnd := time.Date(date.Year(), date.Month(), date.Day(), 23, 0, 0, 0, date.Location())
+	minDuration := time.Duration(minDurationMinutes) * time.Minute
+
+	roomReservations, err := s.GetReservationsByRoom(ctx, roomNumber, block)
+	if err != nil {
+		return nil, err
+	}
+
+	var dayReservations []*Reservation
+	for _, reservation := range roomReservations {
+		if reservation.StartTime.Before(dayEnd) && reservation.EndTime.After(dayStart) {
+			dayReservations = append(dayReservations, reservation)
+		}
+	}
+
+	sort.Slice(dayReservations, func(i, j int) bool {
+		return dayReservations[i].StartTime.Before(dayReservations[j].StartTime)
+	})
+
+	var slots []*TimeSlot
+	cursor := dayStart
+	for _, reservation := range dayReservations {
+		if reservation.StartTime.After(cursor) {
+			if reservation.StartTime.Sub(cursor) >= minDuration {
+				slots = append(slots, &TimeSlot{Start: cursor, End: reservation.StartTime})
+			}
+		}
+		if reservation.EndTime.After(cursor) {
+			cursor = reservation.EndTime
+		}
+	}
+	if dayEnd.After(cursor) && dayEnd.Sub(cursor) >= minDuration {
+		slots = append(slots, &TimeSlot{Start: cursor, End: dayEnd})
+	}
+
+	return slots, nil
+}
+
+// SuggestReservationTimes returns the earliest available (room, start) suggestions across every
+// room in a block for a given date and duration, capped at maxSuggestedReservationTimes.
+func (s *SmartContract) SuggestReservationTimes(ctx contractapi.TransactionContextInterface, block, dateStr string, duration int) ([]*ReservationSuggestion, error) {
+	allRooms, err := s.GetAllRooms(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var suggestions []*ReservationSuggestion
+	for _, room := range allRooms {
+		if room.Block != block {
+			continue
+		}
+
+		slots, err := s.GetRoomAvailabilitySlots(ctx, block, room.Number, dateStr, duration)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, slot := range slots {
+			suggestions = append(suggestions, &ReservationSuggestion{
+				RoomNumber: room.Number,
+				Block:      block,
+				Start:      slot.Start,
+			})
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if !suggestions[i].Start.Equal(suggestions[j].Start) {
+			return suggestions[i].Start.Before(suggestions[j].Start)
+		}
+		return suggestions[i].RoomNumber < suggestions[j].RoomNumber
+	})
+
+	if len(suggestions) > maxSuggestedReservationTimes {
+		suggestions = suggestions[:maxSuggestedReservationTimes]
+	}
+
+	return suggestions, nil
+}
+
+// GetReservationHistory returns the ordered change history of a reservation, so clients can
+// audit who created, modified, or cancelled it and when.
+func (s *SmartContract) GetReservationHistory(ctx contractapi.TransactionContextInterface, reservationID string) ([]*ReservationHistoryEntry, error) {
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey("RESERVATION_" + reservationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history for reservation %s: %v", reservationID, err)
+	}
+	defer resultsIterator.Close()
+
+	var history []*ReservationHistoryEntry
+	for resultsIterator.HasNext() {
+		modification, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		entry := &ReservationHistoryEntry{
+			TxID:     modification.TxId,
+			IsDelete: modification.IsDelete,
+		}
+		if modification.Timestamp != nil {
+			entry.Timestamp = time.Unix(modification.Timestamp.Seconds, int64(modification.Timestamp.Nanos))
+		}
+
+		if !modification.IsDelete {
+			var reservation Reservation
+			if err := json.Unmarshal(modification.Value, &reservation); err != nil {
+				return nil, err
+			}
+			entry.Reservation = &reservation
+		}
+
+		history = append(history, entry)
+	}
+
+	return history, nil
+}
+
 func main() {
 	assetChaincode, err := contractapi.NewChaincode(&SmartContract{})
 	if err != nil {
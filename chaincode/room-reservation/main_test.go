@@ -0,0 +1,506 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+)
+
+func mustParse(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse("2006-01-02 15:04", value)
+	if err != nil {
+		t.Fatalf("failed to parse %q: %v", value, err)
+	}
+	return parsed
+}
+
+func TestExpandRecurrenceRule(t *testing.T) {
+	tests := []struct {
+		name      string
+		firstStart string
+		rrule     string
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name:       "daily with count",
+			firstStart: "2026-08-03 09:00",
+			rrule:      "FREQ=DAILY;COUNT=3",
+			want: []string{
+				"2026-08-03 09:00",
+				"2026-08-04 09:00",
+				"2026-08-05 09:00",
+			},
+		},
+		{
+			name:       "daily with interval",
+			firstStart: "2026-08-03 09:00",
+			rrule:      "FREQ=DAILY;INTERVAL=2;COUNT=3",
+			want: []string{
+				"2026-08-03 09:00",
+				"2026-08-05 09:00",
+				"2026-08-07 09:00",
+			},
+		},
+		{
+			name:       "weekly with byday",
+			firstStart: "2026-08-03 09:00", // a Monday
+			rrule:      "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4",
+			want: []string{
+				"2026-08-03 09:00",
+				"2026-08-05 09:00",
+				"2026-08-10 09:00",
+				"2026-08-12 09:00",
+			},
+		},
+		{
+			// UNTIL with a bare date parses as midnight, so an occurrence later that same day
+			// (09:00, from firstStart's time-of-day) is excluded.
+			name:       "weekly with until",
+			firstStart: "2026-08-03 09:00",
+			rrule:      "FREQ=WEEKLY;UNTIL=2026-08-17",
+			want: []string{
+				"2026-08-03 09:00",
+				"2026-08-10 09:00",
+			},
+		},
+		{
+			name:       "missing freq is an error",
+			firstStart: "2026-08-03 09:00",
+			rrule:      "COUNT=3",
+			wantErr:    true,
+		},
+		{
+			name:       "unsupported freq is an error",
+			firstStart: "2026-08-03 09:00",
+			rrule:      "FREQ=MONTHLY;COUNT=3",
+			wantErr:    true,
+		},
+		{
+			name:       "invalid byday is an error",
+			firstStart: "2026-08-03 09:00",
+			rrule:      "FREQ=WEEKLY;BYDAY=XX;COUNT=3",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			firstStart := mustParse(t, tt.firstStart)
+
+			got, err := expandRecurrenceRule(firstStart, tt.rrule)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expandRecurrenceRule(%q) = %v, want error", tt.rrule, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandRecurrenceRule(%q) returned unexpected error: %v", tt.rrule, err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("expandRecurrenceRule(%q) returned %d occurrences, want %d: %v", tt.rrule, len(got), len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				if got[i].Format("2006-01-02 15:04") != want {
+					t.Errorf("occurrence %d = %s, want %s", i, got[i].Format("2006-01-02 15:04"), want)
+				}
+			}
+		})
+	}
+}
+
+func TestStartOfWeek(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "monday stays on monday", in: "2026-08-03 09:00", want: "2026-08-03 00:00"},
+		{name: "wednesday rolls back to monday", in: "2026-08-05 14:30", want: "2026-08-03 00:00"},
+		{name: "sunday rolls back to monday", in: "2026-08-09 23:00", want: "2026-08-03 00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := mustParse(t, tt.in)
+			got := startOfWeek(in)
+			if got.Format("2006-01-02 15:04") != tt.want {
+				t.Errorf("startOfWeek(%s) = %s, want %s", tt.in, got.Format("2006-01-02 15:04"), tt.want)
+			}
+		})
+	}
+}
+
+// fakeClientIdentity is a minimal cid.ClientIdentity stand-in for unit testing resolveActingUser
+// without a live peer.
+type fakeClientIdentity struct {
+	id         string
+	attributes map[string]string
+}
+
+func (f *fakeClientIdentity) GetID() (string, error) {
+	return f.id, nil
+}
+
+func (f *fakeClientIdentity) GetMSPID() (string, error) {
+	return "Org1MSP", nil
+}
+
+func (f *fakeClientIdentity) GetAttributeValue(attrName string) (string, bool, error) {
+	value, ok := f.attributes[attrName]
+	return value, ok, nil
+}
+
+func (f *fakeClientIdentity) AssertAttributeValue(attrName, attrValue string) error {
+	if value, ok := f.attributes[attrName]; !ok || value != attrValue {
+		return fmt.Errorf("attribute %s does not have value %s", attrName, attrValue)
+	}
+	return nil
+}
+
+func (f *fakeClientIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return nil, nil
+}
+
+// fakeTransactionContext satisfies contractapi.TransactionContextInterface with a
+// shim.ChaincodeStubInterface (usually a shimtest.MockStub, but tests may wrap one to simulate a
+// peer-specific quirk) and a fakeClientIdentity, so contract methods can be exercised against
+// world state directly.
+type fakeTransactionContext struct {
+	stub     shim.ChaincodeStubInterface
+	identity *fakeClientIdentity
+}
+
+func (c *fakeTransactionContext) GetStub() shim.ChaincodeStubInterface {
+	return c.stub
+}
+
+func (c *fakeTransactionContext) GetClientIdentity() cid.ClientIdentity {
+	return c.identity
+}
+
+func newFakeContext(stub shim.ChaincodeStubInterface, clientID string, attributes map[string]string) *fakeTransactionContext {
+	return &fakeTransactionContext{
+		stub:     stub,
+		identity: &fakeClientIdentity{id: clientID, attributes: attributes},
+	}
+}
+
+func registerTestUser(t *testing.T, s *SmartContract, stub *shimtest.MockStub, userID, enrollmentID string) {
+	t.Helper()
+	registrarCtx := newFakeContext(stub, "registrar-cert", map[string]string{"role": "registrar"})
+	stub.MockTransactionStart("register-" + userID)
+	defer stub.MockTransactionEnd("register-" + userID)
+	if err := s.RegisterUser(registrarCtx, userID, "Test User", userID+"@example.edu", "student", "33", enrollmentID); err != nil {
+		t.Fatalf("RegisterUser(%s) failed: %v", userID, err)
+	}
+}
+
+func TestResolveActingUser(t *testing.T) {
+	s := new(SmartContract)
+
+	t.Run("resolves the user linked to the calling identity", func(t *testing.T) {
+		stub := shimtest.NewMockStub("room-reservation", nil)
+		registerTestUser(t, s, stub, "u1", "enrollment-1")
+
+		ctx := newFakeContext(stub, "enrollment-1", nil)
+		user, err := s.resolveActingUser(ctx, "")
+		if err != nil {
+			t.Fatalf("resolveActingUser returned unexpected error: %v", err)
+		}
+		if user.ID != "u1" {
+			t.Errorf("resolveActingUser resolved user %q, want %q", user.ID, "u1")
+		}
+	})
+
+	t.Run("errors when no registered user is linked to the calling identity", func(t *testing.T) {
+		stub := shimtest.NewMockStub("room-reservation", nil)
+		ctx := newFakeContext(stub, "unknown-cert", nil)
+
+		if _, err := s.resolveActingUser(ctx, ""); err == nil {
+			t.Fatal("resolveActingUser with no linked user = nil error, want error")
+		}
+	})
+
+	t.Run("admin override is rejected without role=admin", func(t *testing.T) {
+		stub := shimtest.NewMockStub("room-reservation", nil)
+		registerTestUser(t, s, stub, "u1", "enrollment-1")
+		registerTestUser(t, s, stub, "u2", "enrollment-2")
+
+		ctx := newFakeContext(stub, "enrollment-1", nil)
+		if _, err := s.resolveActingUser(ctx, "u2"); err == nil {
+			t.Fatal("resolveActingUser with admin override and no role=admin = nil error, want error")
+		}
+	})
+
+	t.Run("admin override resolves the specified user when role=admin", func(t *testing.T) {
+		stub := shimtest.NewMockStub("room-reservation", nil)
+		registerTestUser(t, s, stub, "u1", "enrollment-1")
+		registerTestUser(t, s, stub, "u2", "enrollment-2")
+
+		ctx := newFakeContext(stub, "enrollment-1", map[string]string{"role": "admin"})
+		user, err := s.resolveActingUser(ctx, "u2")
+		if err != nil {
+			t.Fatalf("resolveActingUser with admin override returned unexpected error: %v", err)
+		}
+		if user.ID != "u2" {
+			t.Errorf("resolveActingUser with admin override resolved user %q, want %q", user.ID, "u2")
+		}
+	})
+}
+
+// newPolicyTestFixture returns a stub seeded with a registered student (u1, linked to client
+// identity "enrollment-1") and room 33/101, for exercising MakeReservation/CancelReservation's
+// ReservationPolicy enforcement.
+func newPolicyTestFixture(t *testing.T) (*shimtest.MockStub, *SmartContract) {
+	t.Helper()
+	s := new(SmartContract)
+	stub := shimtest.NewMockStub("room-reservation", nil)
+	registerTestUser(t, s, stub, "u1", "enrollment-1")
+
+	room := Room{Number: "101", Block: "33", Floor: "1", Status: "available"}
+	roomJSON, err := json.Marshal(room)
+	if err != nil {
+		t.Fatalf("failed to marshal room: %v", err)
+	}
+	stub.MockTransactionStart("seed-room")
+	if err := stub.PutState("ROOM_33_101", roomJSON); err != nil {
+		t.Fatalf("failed to seed room: %v", err)
+	}
+	stub.MockTransactionEnd("seed-room")
+
+	return stub, s
+}
+
+// setTxTime pins the stub's transaction timestamp, standing in for ctx.GetStub().GetTxTimestamp()
+// so lead-time/advance-booking/grace-period checks can be tested against a fixed reference time
+// instead of the real wall clock.
+func setTxTime(stub *shimtest.MockStub, at time.Time) {
+	stub.TxTimestamp = &timestamp.Timestamp{Seconds: at.Unix(), Nanos: int32(at.Nanosecond())}
+}
+
+func makeReservationAt(s *SmartContract, stub *shimtest.MockStub, reservationID, startTimeStr string, txTime time.Time) error {
+	ctx := newFakeContext(stub, "enrollment-1", nil)
+	stub.MockTransactionStart(reservationID)
+	defer stub.MockTransactionEnd(reservationID)
+	setTxTime(stub, txTime)
+	return s.MakeReservation(ctx, reservationID, "101", "33", startTimeStr, 60, "", "")
+}
+
+func cancelReservationAt(s *SmartContract, stub *shimtest.MockStub, reservationID string, txTime time.Time) error {
+	ctx := newFakeContext(stub, "enrollment-1", nil)
+	stub.MockTransactionStart("cancel-" + reservationID)
+	defer stub.MockTransactionEnd("cancel-" + reservationID)
+	setTxTime(stub, txTime)
+	return s.CancelReservation(ctx, reservationID, "")
+}
+
+func TestMakeReservationPolicy(t *testing.T) {
+	referenceNow := time.Date(2026, 8, 3, 10, 0, 0, 0, time.UTC) // a Monday
+
+	t.Run("rejects a reservation inside the minimum lead time", func(t *testing.T) {
+		stub, s := newPolicyTestFixture(t)
+		if err := makeReservationAt(s, stub, "r1", "2026-08-03 10:20", referenceNow); err == nil {
+			t.Fatal("MakeReservation with 20 minutes of lead time = nil error, want error")
+		}
+	})
+
+	t.Run("accepts a reservation that satisfies lead time and advance-booking limits", func(t *testing.T) {
+		stub, s := newPolicyTestFixture(t)
+		if err := makeReservationAt(s, stub, "r1", "2026-08-05 10:00", referenceNow); err != nil {
+			t.Fatalf("MakeReservation returned unexpected error: %v", err)
+		}
+	})
+
+	t.Run("rejects a reservation beyond the maximum advance-booking window", func(t *testing.T) {
+		stub, s := newPolicyTestFixture(t)
+		if err := makeReservationAt(s, stub, "r1", "2026-09-12 10:00", referenceNow); err == nil {
+			t.Fatal("MakeReservation 40 days out = nil error, want error")
+		}
+	})
+
+	t.Run("rejects once the weekly active-reservation quota is reached", func(t *testing.T) {
+		stub, s := newPolicyTestFixture(t)
+		starts := []string{"2026-08-04 10:00", "2026-08-05 10:00", "2026-08-06 10:00"}
+		for i, start := range starts {
+			reservationID := fmt.Sprintf("r%d", i)
+			if err := makeReservationAt(s, stub, reservationID, start, referenceNow); err != nil {
+				t.Fatalf("MakeReservation(%s) returned unexpected error: %v", start, err)
+			}
+		}
+
+		// A 4th occurrence in the same week exceeds the default MaxActivePerStudent of 3.
+		if err := makeReservationAt(s, stub, "r-over-quota", "2026-08-07 10:00", referenceNow); err == nil {
+			t.Fatal("MakeReservation beyond MaxActivePerStudent = nil error, want error")
+		}
+	})
+
+	t.Run("rejects once the weekly hour quota is reached", func(t *testing.T) {
+		stub, s := newPolicyTestFixture(t)
+
+		policy := defaultReservationPolicy
+		policy.MaxActivePerStudent = 10
+		policy.MaxHoursPerWeekPerUser = 2
+
+		adminCtx := newFakeContext(stub, "admin-cert", map[string]string{"role": "admin"})
+		stub.MockTransactionStart("set-policy")
+		err := s.SetPolicy(adminCtx, policy.MaxActivePerStudent, policy.MaxActivePerProfessor,
+			policy.MaxHoursPerWeekPerUser, policy.MinLeadTimeMinutes, policy.MaxAdvanceDays,
+			policy.CancellationGraceMinutes, policy.AllowProfessorOverride)
+		stub.MockTransactionEnd("set-policy")
+		if err != nil {
+			t.Fatalf("SetPolicy returned unexpected error: %v", err)
+		}
+
+		// Two 60-minute reservations exactly fill the 2-hour weekly cap; a third must be rejected.
+		if err := makeReservationAt(s, stub, "r1", "2026-08-04 10:00", referenceNow); err != nil {
+			t.Fatalf("MakeReservation returned unexpected error: %v", err)
+		}
+		if err := makeReservationAt(s, stub, "r2", "2026-08-05 10:00", referenceNow); err != nil {
+			t.Fatalf("MakeReservation returned unexpected error: %v", err)
+		}
+		if err := makeReservationAt(s, stub, "r-over-hours", "2026-08-06 10:00", referenceNow); err == nil {
+			t.Fatal("MakeReservation beyond MaxHoursPerWeekPerUser = nil error, want error")
+		}
+	})
+}
+
+func TestCancelReservationGracePeriod(t *testing.T) {
+	referenceNow := time.Date(2026, 8, 3, 10, 0, 0, 0, time.UTC) // a Monday
+	const startTimeStr = "2026-08-05 10:00"
+
+	t.Run("rejects cancellation once the grace period has closed", func(t *testing.T) {
+		stub, s := newPolicyTestFixture(t)
+		if err := makeReservationAt(s, stub, "r1", startTimeStr, referenceNow); err != nil {
+			t.Fatalf("MakeReservation returned unexpected error: %v", err)
+		}
+
+		tooLate := mustParse(t, startTimeStr).Add(-30 * time.Minute) // inside the default 60-minute grace period
+		if err := cancelReservationAt(s, stub, "r1", tooLate); err == nil {
+			t.Fatal("CancelReservation inside the grace period = nil error, want error")
+		}
+	})
+
+	t.Run("accepts cancellation before the grace period closes", func(t *testing.T) {
+		stub, s := newPolicyTestFixture(t)
+		if err := makeReservationAt(s, stub, "r1", startTimeStr, referenceNow); err != nil {
+			t.Fatalf("MakeReservation returned unexpected error: %v", err)
+		}
+
+		wellBefore := mustParse(t, startTimeStr).Add(-2 * time.Hour)
+		if err := cancelReservationAt(s, stub, "r1", wellBefore); err != nil {
+			t.Fatalf("CancelReservation before the grace period returned unexpected error: %v", err)
+		}
+	})
+}
+
+// seedActiveReservation writes an active reservation directly to world state (bypassing
+// MakeReservation's policy checks), for tests that only care about read-side logic built on top
+// of existing reservations.
+func seedActiveReservation(t *testing.T, s *SmartContract, stub *shimtest.MockStub, id, roomNumber, block, userID, startTimeStr, endTimeStr string) {
+	t.Helper()
+	ctx := newFakeContext(stub, "seed", nil)
+	stub.MockTransactionStart("seed-" + id)
+	defer stub.MockTransactionEnd("seed-" + id)
+
+	reservation := Reservation{
+		ID:         id,
+		RoomNumber: roomNumber,
+		Block:      block,
+		UserID:     userID,
+		StartTime:  mustParse(t, startTimeStr),
+		EndTime:    mustParse(t, endTimeStr),
+		Status:     "active",
+	}
+	if err := s.putReservationWithIndexes(ctx, reservation); err != nil {
+		t.Fatalf("failed to seed reservation %s: %v", id, err)
+	}
+}
+
+func TestGetRoomAvailabilitySlots(t *testing.T) {
+	t.Run("back-to-back reservations leave no gap between them", func(t *testing.T) {
+		s := new(SmartContract)
+		stub := shimtest.NewMockStub("room-reservation", nil)
+		seedActiveReservation(t, s, stub, "r1", "101", "33", "u1", "2026-08-03 09:00", "2026-08-03 10:00")
+		seedActiveReservation(t, s, stub, "r2", "101", "33", "u1", "2026-08-03 10:00", "2026-08-03 11:00")
+
+		ctx := newFakeContext(stub, "viewer", nil)
+		slots, err := s.GetRoomAvailabilitySlots(ctx, "33", "101", "2026-08-03", 30)
+		if err != nil {
+			t.Fatalf("GetRoomAvailabilitySlots returned unexpected error: %v", err)
+		}
+
+		want := []string{"06:00-09:00", "11:00-23:00"}
+		if len(slots) != len(want) {
+			t.Fatalf("GetRoomAvailabilitySlots returned %d slots, want %d: %v", len(slots), len(want), slots)
+		}
+		for i, slot := range slots {
+			got := slot.Start.Format("15:04") + "-" + slot.End.Format("15:04")
+			if got != want[i] {
+				t.Errorf("slot %d = %s, want %s", i, got, want[i])
+			}
+		}
+	})
+
+	t.Run("a reservation spanning the day boundary clips to the window instead of a negative-length slot", func(t *testing.T) {
+		s := new(SmartContract)
+		stub := shimtest.NewMockStub("room-reservation", nil)
+		seedActiveReservation(t, s, stub, "r1", "101", "33", "u1", "2026-08-02 23:30", "2026-08-03 07:00")
+
+		ctx := newFakeContext(stub, "viewer", nil)
+		slots, err := s.GetRoomAvailabilitySlots(ctx, "33", "101", "2026-08-03", 30)
+		if err != nil {
+			t.Fatalf("GetRoomAvailabilitySlots returned unexpected error: %v", err)
+		}
+
+		if len(slots) != 1 {
+			t.Fatalf("GetRoomAvailabilitySlots returned %d slots, want 1: %v", len(slots), slots)
+		}
+		if got := slots[0].Start.Format("15:04"); got != "07:00" {
+			t.Errorf("slot start = %s, want 07:00 (clipped to the spanning reservation's end)", got)
+		}
+		if got := slots[0].End.Format("15:04"); got != "23:00" {
+			t.Errorf("slot end = %s, want 23:00", got)
+		}
+	})
+}
+
+// unsupportedQueryStub wraps a shimtest.MockStub to simulate a goleveldb-backed peer, where
+// GetQueryResult (CouchDB rich queries) is unavailable, so GetReservationsByDateRange must fall
+// back to a full scan.
+type unsupportedQueryStub struct {
+	*shimtest.MockStub
+}
+
+func (s *unsupportedQueryStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	return nil, errors.New("GetQueryResult not supported for leveldb")
+}
+
+func TestGetReservationsByDateRangeFallback(t *testing.T) {
+	s := new(SmartContract)
+	mockStub := shimtest.NewMockStub("room-reservation", nil)
+	seedActiveReservation(t, s, mockStub, "r1", "101", "33", "u1", "2026-08-03 09:00", "2026-08-03 10:00")
+	seedActiveReservation(t, s, mockStub, "r2", "101", "33", "u1", "2026-09-01 09:00", "2026-09-01 10:00")
+
+	ctx := newFakeContext(&unsupportedQueryStub{MockStub: mockStub}, "viewer", nil)
+
+	reservations, err := s.GetReservationsByDateRange(ctx, "2026-08-01", "2026-08-31")
+	if err != nil {
+		t.Fatalf("GetReservationsByDateRange returned unexpected error: %v", err)
+	}
+
+	if len(reservations) != 1 || reservations[0].ID != "r1" {
+		t.Fatalf("GetReservationsByDateRange (fallback) = %v, want only r1", reservations)
+	}
+}